@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cachePath returns the file holding the last file list opened in dir,
+// under $XDG_CACHE_HOME/pyvim keyed by a hash of the absolute dir.
+func cachePath(dir string) (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(dir))
+	return filepath.Join(base, "pyvim", hex.EncodeToString(sum[:])), nil
+}
+
+// saveFileList records files as the last list opened in dir.
+func saveFileList(dir string, files []string) error {
+	path, err := cachePath(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(files, "\n")+"\n"), 0o644)
+}
+
+// loadFileList returns the last list opened in dir, with paths relative
+// to dir as they were saved.
+func loadFileList(dir string) ([]string, error) {
+	path, err := cachePath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate cache: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no saved file list to resume in %s", dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %v", err)
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
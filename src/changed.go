@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// changedFiles returns the absolute paths of files modified or added in
+// the git repository containing dir. With base set, changes are taken
+// from a diff against that ref instead of the working tree status.
+// Deleted files are left out since they cannot be opened.
+func changedFiles(dir, base string) (map[string]bool, error) {
+	out, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("--changed requires a git repository: %v", err)
+	}
+	top := realPath(strings.TrimSpace(string(out)))
+
+	var paths []string
+	if base != "" {
+		out, err := git(dir, "diff", "--name-only", "-z", "--diff-filter=d", base)
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff against %s: %v", base, err)
+		}
+		paths = splitNul(out)
+	} else {
+		out, err := git(dir, "status", "--porcelain", "-z", "--untracked-files=all")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read git status: %v", err)
+		}
+		entries := splitNul(out)
+		for i := 0; i < len(entries); i++ {
+			entry := entries[i]
+			if len(entry) < 4 {
+				continue
+			}
+			status, path := entry[:2], entry[3:]
+
+			// Renames and copies are followed by their source path
+			if status[0] == 'R' || status[0] == 'C' {
+				i++
+			}
+			if strings.Contains(status, "D") {
+				continue
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	// git reports paths from the repository root
+	changed := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		changed[filepath.Join(top, filepath.FromSlash(p))] = true
+	}
+	return changed, nil
+}
+
+// filterChanged keeps the files present in changed.
+func filterChanged(files []string, changed map[string]bool) []string {
+	var kept []string
+	for _, f := range files {
+		if changed[realPath(f)] {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// realPath returns the absolute path of f with symlinks in its directory
+// resolved, so it compares equal to the paths git reports. It falls back
+// to the cleaned path when f cannot be resolved.
+func realPath(f string) string {
+	abs, err := filepath.Abs(f)
+	if err != nil {
+		return filepath.Clean(f)
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return abs
+	}
+	return filepath.Join(dir, filepath.Base(abs))
+}
+
+// git runs a git subcommand in dir, returning its stdout. The error
+// includes git's own message when there is one.
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func splitNul(b []byte) []string {
+	var parts []string
+	for _, p := range strings.Split(string(b), "\x00") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
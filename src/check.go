@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// compileFailure is a file that python could not compile.
+type compileFailure struct {
+	path string
+	msg  string
+}
+
+// checkSyntax compiles each of files with python -m py_compile, running
+// up to one check per CPU at a time. Failures keep the order of files.
+// Bytecode goes to a temporary cache so the project tree is untouched.
+func checkSyntax(python string, files []string) []compileFailure {
+	env := append(os.Environ(), "PYTHONPYCACHEPREFIX="+filepath.Join(os.TempDir(), "pyvim-pycache"))
+	results := make([]*compileFailure, len(files))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = compileFile(python, files[i], env)
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var failures []compileFailure
+	for _, r := range results {
+		if r != nil {
+			failures = append(failures, *r)
+		}
+	}
+	return failures
+}
+
+func compileFile(python, path string, env []string) *compileFailure {
+	cmd := exec.Command(python, "-m", "py_compile", path)
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	return &compileFailure{path: path, msg: firstErrorLine(stderr.String(), err)}
+}
+
+// firstErrorLine picks the line naming the error, such as
+// "SyntaxError: invalid syntax", from py_compile's output.
+func firstErrorLine(output string, err error) string {
+	var first string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if first == "" {
+			first = line
+		}
+		if strings.Contains(line, "Error") && !strings.HasPrefix(line, "File ") {
+			return line
+		}
+	}
+	if first == "" {
+		return err.Error()
+	}
+	return first
+}
@@ -1,47 +1,556 @@
-
-package main
-
-import (
-	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
-)
-
-func handlePyVim() error {
-	// Check if vim is installed
-	_, err := exec.LookPath("vim")
-	if err != nil {
-		return fmt.Errorf("vim is not installed: %v", err)
-	}
-
-	// Check if python is installed
-	_, err = exec.LookPath("python")
-	if err != nil {
-		return fmt.Errorf("python is not installed: %v", err)
-	}
-
-	// Get current working directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get working directory: %v", err)
-	}
-
-	// Find Python files in current directory
-	pyFiles, err := filepath.Glob("*.py")
-	if err != nil {
-		return fmt.Errorf("failed to find Python files: %v", err)
-	}
-
-	if len(pyFiles) == 0 {
-		return fmt.Errorf("no Python files found in %s", cwd)
-	}
-
-	// Launch vim with Python files
-	cmd := exec.Command("vim", pyFiles...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
-}
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// verbose logs diagnostics to stderr; it is silent unless --verbose is set.
+var verbose = log.New(io.Discard, "pyvim: ", log.LstdFlags)
+
+// notice prints informational messages to stderr unless --quiet is set.
+var notice = log.New(os.Stderr, "pyvim: ", 0)
+
+// resolveEditor picks the editor from the --editor flag, then $EDITOR,
+// then falls back to vim. The second return value names the source.
+func resolveEditor(opts Options) (string, string) {
+	if opts.editor != "" {
+		return opts.editor, "--editor flag"
+	}
+	if env := os.Getenv("EDITOR"); env != "" {
+		return env, "$EDITOR"
+	}
+	return "vim", "default"
+}
+
+// resolvePython looks up the interpreter named by --python, or the
+// first of python3 and python in the virtualenv (when set) or on PATH.
+func resolvePython(opts Options) (string, error) {
+	candidates := []string{"python3", "python"}
+	if opts.pythonCmd != "" {
+		candidates = []string{opts.pythonCmd}
+	} else if opts.venv != "" {
+		bin := venvBinDir(opts.venv)
+		candidates = []string{filepath.Join(bin, "python3"), filepath.Join(bin, "python")}
+	}
+
+	for _, name := range candidates {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("python is not installed: tried %s", strings.Join(candidates, ", "))
+}
+
+// defaultTabPageMax is vim's default 'tabpagemax'.
+const defaultTabPageMax = 10
+
+// editorArgs builds the command line for editor on files. A restored
+// session replaces the file list.
+func editorArgs(editor string, files []string, opts Options, restoreSession bool) []string {
+	var args []string
+	if opts.plain {
+		args = append(args, plainArgs(editor, opts.editorKind)...)
+	}
+	if opts.diff {
+		args = append(args, "-d")
+	}
+	if opts.view {
+		args = append(args, "-R")
+	}
+	if opts.tabs {
+		if !containsArg(opts.extraArgs, "-p") {
+			args = append(args, "-p")
+		}
+		if len(files) > defaultTabPageMax {
+			args = append(args, "--cmd", fmt.Sprintf("set tabpagemax=%d", len(files)))
+		}
+	}
+	if opts.gotoTarget != nil {
+		args = append(args, opts.gotoTarget.args()...)
+	}
+	args = append(args, opts.extraArgs...)
+	if opts.sessionPath != "" {
+		args = append(args, sessionArgs(opts.sessionPath, restoreSession)...)
+		if restoreSession {
+			return args
+		}
+	}
+	return append(args, files...)
+}
+
+func containsArg(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// changeDir validates dir and makes it the working directory,
+// returning its absolute path.
+func changeDir(dir string) (string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid --chdir %s: %v", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("invalid --chdir %s: not a directory", dir)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid --chdir %s: %v", dir, err)
+	}
+	if err := os.Chdir(abs); err != nil {
+		return "", fmt.Errorf("failed to change directory: %v", err)
+	}
+	return abs, nil
+}
+
+// checkTerminal reports an error unless the editor can be attached to
+// a terminal. With --stdin the editor reads /dev/tty instead of stdin.
+func checkTerminal(opts Options) error {
+	var stream string
+	switch {
+	case !isTerminal(os.Stdout):
+		stream = "stdout"
+	case !opts.stdin && !isTerminal(os.Stdin):
+		stream = "stdin"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%s is not a terminal, so an interactive editor cannot run; use --dry-run, --count, or --json when piping", stream)
+}
+
+// warnf prints a non-fatal warning to stderr.
+func warnf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "pyvim: warning: "+format+"\n", args...)
+}
+
+// discoverFiles resolves the positional arguments, stdin list, and
+// directory searches into the ordered list of files to open. Relative
+// paths are resolved against root, and files are returned the way they
+// were named: found under a relative argument, they stay relative.
+func discoverFiles(root string, opts Options) ([]string, error) {
+	if opts.diff {
+		return diffFiles(root, opts.paths, opts.wantsFile)
+	}
+	if opts.resume {
+		return loadFileList(root)
+	}
+
+	// Split positional arguments into explicit files and directories
+	dirs, explicit, err := splitArgs(root, opts.paths)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the file list from stdin, or default to the working directory
+	var listed []string
+	if opts.stdin {
+		listed, err = readFileList(os.Stdin, root, opts.wantsFile)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(dirs) == 0 && len(explicit) == 0 {
+		dirs = []string{"."}
+	}
+
+	// Filters work on paths resolved against root; shown maps each back
+	// to the way it was named
+	shown := make(map[string]string)
+	show := func(path, name string) {
+		if _, ok := shown[path]; !ok {
+			shown[path] = name
+		}
+	}
+	resolve := func(name string) string {
+		path := resolvePath(root, name)
+		show(path, name)
+		return path
+	}
+	for i, f := range explicit {
+		explicit[i] = resolve(f)
+	}
+
+	// Load each repository's .gitignore once
+	ignores := make(map[string]*gitignore)
+	ignoreFor := func(dir string) (*gitignore, error) {
+		if g, ok := ignores[dir]; ok {
+			return g, nil
+		}
+		g, err := loadGitignore(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read .gitignore: %v", err)
+		}
+		ignores[dir] = g
+		return g, nil
+	}
+
+	// Find Python files in each directory
+	var pyFiles []string
+	for _, dir := range dirs {
+		verbose.Printf("searching %s (recursive=%t)", dir, opts.recursive)
+		abs := resolvePath(root, dir)
+		found, err := findPyFiles(abs, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find Python files: %v", err)
+		}
+		for _, f := range found {
+			rel, err := filepath.Rel(abs, f)
+			if err != nil {
+				return nil, err
+			}
+			show(f, filepath.Join(dir, rel))
+		}
+		verbose.Printf("found %d matching files in %s", len(found), dir)
+
+		// Drop files excluded by the .gitignore of the repository
+		// holding dir
+		if !opts.noGitignore {
+			ignore, err := ignoreFor(abs)
+			if err != nil {
+				return nil, err
+			}
+			before := len(found)
+			found = ignore.filter(root, found)
+			verbose.Printf(".gitignore removed %d files in %s", before-len(found), dir)
+		}
+		pyFiles = append(pyFiles, found...)
+	}
+
+	// Listed files go through the same filters as found ones, starting
+	// with the .gitignore of the repository holding each
+	for _, f := range listed {
+		path := resolve(f)
+		if !opts.noGitignore {
+			ignore, err := ignoreFor(filepath.Dir(path))
+			if err != nil {
+				return nil, err
+			}
+			if ignore.ignored(path) {
+				verbose.Printf(".gitignore removed %s", f)
+				continue
+			}
+		}
+		pyFiles = append(pyFiles, path)
+	}
+
+	// Drop test files
+	if opts.noTests {
+		before := len(pyFiles)
+		pyFiles = filterTests(pyFiles)
+		verbose.Printf("--no-tests removed %d files", before-len(pyFiles))
+	}
+
+	// Drop files matching --exclude patterns, matched against the paths
+	// as named
+	if len(opts.excludePatterns) > 0 {
+		before := len(pyFiles)
+		pyFiles = filterExcluded(pyFiles, opts.excludePatterns, func(f string) string { return shown[f] })
+		verbose.Printf("--exclude removed %d files", before-len(pyFiles))
+	}
+
+	// Keep only recently modified files
+	if opts.since > 0 {
+		before := len(pyFiles)
+		pyFiles = filterSince(pyFiles, time.Now().Add(-opts.since))
+		verbose.Printf("--since removed %d files", before-len(pyFiles))
+	}
+
+	// Keep only files git reports as changed
+	if opts.changed {
+		changed, err := changedFiles(root, opts.changedBase)
+		if err != nil {
+			return nil, err
+		}
+		before := len(pyFiles)
+		pyFiles = filterChanged(pyFiles, changed)
+		verbose.Printf("--changed removed %d files", before-len(pyFiles))
+	}
+
+	// Keep only files in packages
+	if opts.byPkg {
+		before := len(pyFiles)
+		var ok bool
+		if pyFiles, ok = filterPackages(pyFiles); !ok {
+			warnf("no Python packages found, opening all files")
+		}
+		verbose.Printf("--by-package removed %d files", before-len(pyFiles))
+	}
+
+	// Explicitly named files are always opened
+	pyFiles = dedupe(append(explicit, pyFiles...))
+
+	sortFiles(pyFiles, opts.sortMode)
+	if opts.reverse {
+		slices.Reverse(pyFiles)
+	}
+
+	// Open each package's files together
+	if opts.byPkg {
+		pyFiles = groupByDir(pyFiles)
+	}
+
+	// Open the --goto file first, counting it against --first and
+	// --max-files
+	if opts.gotoTarget != nil {
+		if err := opts.gotoTarget.validate(root, opts.wantsFile); err != nil {
+			return nil, err
+		}
+		pyFiles = moveToFront(pyFiles, resolve(opts.gotoTarget.file))
+	}
+
+	// Keep only the newest file, or the --goto file
+	if opts.first {
+		if opts.gotoTarget != nil {
+			pyFiles = pyFiles[:1]
+		} else {
+			pyFiles = newestFile(pyFiles)
+		}
+	}
+
+	// Truncate to the requested number of files; --pick lets the user
+	// choose instead
+	if opts.maxFiles > 0 && len(pyFiles) > opts.maxFiles && !opts.pick {
+		warnf("opening %d of %d files, %d omitted by --max-files", opts.maxFiles, len(pyFiles), len(pyFiles)-opts.maxFiles)
+		pyFiles = pyFiles[:opts.maxFiles]
+	}
+
+	for i, f := range pyFiles {
+		pyFiles[i] = shown[f]
+	}
+	return pyFiles, nil
+}
+
+// launchEditor runs editor on files, or on the --session file when
+// restoreSession is set, attached to the terminal, and waits for it to
+// exit.
+func launchEditor(editor string, files []string, opts Options, restoreSession bool) error {
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, editor, editorArgs(editor, files, opts, restoreSession)...)
+	cmd.Stdin = os.Stdin
+	if opts.stdin {
+		// stdin held the file list, so give the editor the terminal
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return fmt.Errorf("cannot open /dev/tty for the editor: %v", err)
+		}
+		defer tty.Close()
+		cmd.Stdin = tty
+	}
+
+	// Give the editor its own process group so a timeout also kills
+	// anything it spawned
+	restoreTerminal := func() {}
+	if opts.timeout > 0 {
+		tty, _ := cmd.Stdin.(*os.File)
+		restoreTerminal = killProcessGroup(cmd, tty)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Dir = opts.chdir
+	if opts.venv != "" {
+		cmd.Env = venvEnv(os.Environ(), opts.venv)
+	}
+
+	err := cmd.Run()
+	restoreTerminal()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("editor timed out after %s", opts.timeout)
+	}
+	return err
+}
+
+func handlePyVim(opts Options) error {
+	if opts.verbose {
+		verbose.SetOutput(os.Stderr)
+	}
+	if opts.quiet {
+		notice.SetOutput(io.Discard)
+	}
+
+	// Move to the --chdir directory so relative paths resolve there
+	if opts.chdir != "" {
+		dir, err := changeDir(opts.chdir)
+		if err != nil {
+			return err
+		}
+		opts.chdir = dir
+	}
+
+	// With --allow-empty the terminal is only needed once files are found
+	if opts.launches() && !opts.allowEmpty {
+		if err := checkTerminal(opts); err != nil {
+			return err
+		}
+	}
+
+	// Check if the editor is installed
+	editor, source := resolveEditor(opts)
+	editorPath, err := exec.LookPath(editor)
+	if err != nil {
+		return fmt.Errorf("editor %q (from %s) is not installed: %v", editor, source, err)
+	}
+	verbose.Printf("editor: %s (from %s)", editorPath, source)
+
+	// Get current working directory
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %v", err)
+	}
+
+	// Detect a project virtualenv
+	if !opts.noVenv {
+		opts.venv = findVenv(cwd)
+		if opts.venv != "" {
+			verbose.Printf("virtualenv: %s", opts.venv)
+		}
+	}
+
+	// Check if python is installed
+	pythonPath, err := resolvePython(opts)
+	if err != nil {
+		return err
+	}
+	verbose.Printf("python: %s", pythonPath)
+
+	pyFiles, err := discoverFiles(cwd, opts)
+	if err != nil {
+		return err
+	}
+	pyFiles = dropMissing(pyFiles)
+
+	// An empty JSON report or a zero count is still a valid answer
+	if len(pyFiles) == 0 && !opts.json && !opts.count {
+		if opts.allowEmpty {
+			notice.Printf("no Python files found in %s", cwd)
+			return nil
+		}
+		return fmt.Errorf("no Python files found in %s", cwd)
+	}
+	if opts.launches() && opts.allowEmpty {
+		if err := checkTerminal(opts); err != nil {
+			return err
+		}
+	}
+
+	// Report files that fail to compile
+	if opts.check || opts.checkOnly {
+		failures := checkSyntax(pythonPath, pyFiles)
+		for _, f := range failures {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", f.path, f.msg)
+		}
+		if opts.checkOnly {
+			if len(failures) > 0 {
+				return fmt.Errorf("%d of %d files failed to compile", len(failures), len(pyFiles))
+			}
+			return nil
+		}
+	}
+
+	// Add companion files after the Python files
+	if opts.openCont {
+		pyFiles = addCompanions(pyFiles, opts.companions)
+	}
+
+	// Print the file list instead of launching the editor
+	if opts.count {
+		fmt.Println(len(pyFiles))
+		return nil
+	}
+	if opts.json {
+		return writeJSON(os.Stdout, editorPath, pythonPath, cwd, pyFiles)
+	}
+	if opts.dryRun {
+		for _, f := range pyFiles {
+			fmt.Println(f)
+		}
+		return nil
+	}
+
+	// Let the user choose when there are more files than --max-files
+	if opts.pick && (opts.maxFiles == 0 || len(pyFiles) > opts.maxFiles) {
+		pyFiles, err = pickFiles(pyFiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts.editorKind = detectEditor(editorPath)
+	verbose.Printf("editor kind: %s", opts.editorKind)
+
+	// Remember the file list for --resume
+	if err := saveFileList(cwd, pyFiles); err != nil {
+		warnf("failed to save file list: %v", err)
+	}
+
+	// Restore or start a session file
+	restoreSession := false
+	if opts.sessionPath != "" {
+		restoreSession, err = prepareSession(opts.sessionPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	if err := launchEditor(editorPath, pyFiles, opts, restoreSession); err != nil {
+		return err
+	}
+	elapsed := time.Since(start).Round(100 * time.Millisecond)
+	if restoreSession {
+		notice.Printf("restored session %s with %s (%s)", opts.sessionPath, filepath.Base(editorPath), elapsed)
+	} else {
+		notice.Printf("edited %d Python file(s) in %s with %s (%s)", len(pyFiles), cwd, filepath.Base(editorPath), elapsed)
+	}
+	return nil
+}
+
+// exitCode maps an editor exit error to a process exit status, using
+// 128+signal when the editor was killed by a signal.
+func exitCode(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return err.ExitCode()
+}
+
+func main() {
+	if err := dispatch(os.Args[1:]); err != nil {
+		// The FlagSet has already printed usage errors and help
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			os.Exit(2)
+		}
+
+		// Exit with the editor's own status when it fails
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitCode(exitErr))
+		}
+		fmt.Fprintf(os.Stderr, "pyvim: %v\n", err)
+		os.Exit(1)
+	}
+}
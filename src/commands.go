@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// command is a pyvim subcommand. Invocations that do not start with a
+// command name run edit, so plain `pyvim [flags] [paths]` still works.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"edit", "open Python files in the editor (default)", runEdit},
+		{"list", "print the files edit would open", runList},
+		{"version", "print version information", runVersion},
+		{"completion", "print a bash or zsh completion script", runCompletion},
+	}
+}
+
+// dispatch runs the command named by args[0], or edit. An existing file
+// or directory named like a command is opened by edit as before
+// commands existed.
+func dispatch(args []string) error {
+	if len(args) > 0 {
+		for _, c := range commands {
+			if c.name != args[0] {
+				continue
+			}
+			if _, err := os.Stat(args[0]); err == nil {
+				verbose.Printf("%s is a path, not the %s command", args[0], c.name)
+				break
+			}
+			return c.run(args[1:])
+		}
+	}
+	return runEdit(args)
+}
+
+func runEdit(args []string) error {
+	opts, err := parseOptions(args)
+	if err != nil {
+		return err
+	}
+	if opts.version {
+		printVersion()
+		return nil
+	}
+	return handlePyVim(opts)
+}
+
+func runList(args []string) error {
+	defaults, err := defaultArgs()
+	if err != nil {
+		return err
+	}
+	var opts Options
+	fs := newListFlagSet(&opts)
+	// Defaults may hold flags only edit defines
+	defaults = keepFlags(defaults, fs, newFlagSet(&Options{}))
+	if err := parseFlags(fs, &opts, defaults, args); err != nil {
+		return err
+	}
+	opts.dryRun = true
+	return handlePyVim(opts)
+}
+
+func runVersion(args []string) error {
+	fs := flag.NewFlagSet("pyvim version", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pyvim version")
+	}
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return usageError{fmt.Errorf("version takes no arguments")}
+	}
+	printVersion()
+	return nil
+}
+
+func runCompletion(args []string) error {
+	fs := flag.NewFlagSet("pyvim completion", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pyvim completion bash|zsh")
+	}
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return usageError{fmt.Errorf("completion takes exactly one shell name")}
+	}
+	return writeCompletion(os.Stdout, newFlagSet(&Options{}), fs.Arg(0))
+}
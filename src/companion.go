@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultCompanions are the files --open-containing looks for next to
+// each Python file.
+const defaultCompanions = "pyproject.toml,setup.cfg,README.md"
+
+// parseCompanions splits a comma-separated --companions value into file
+// names, ignoring surrounding whitespace.
+func parseCompanions(spec string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if filepath.IsAbs(name) {
+			return nil, fmt.Errorf("invalid --companions entry %q: must be relative", name)
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("invalid --companions %q: no file names given", spec)
+	}
+	return names, nil
+}
+
+// addCompanions appends the companion files that exist in each directory
+// containing one of files. Directories are visited in order of first
+// appearance and each companion is added once.
+func addCompanions(files, names []string) []string {
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		seen[filepath.Clean(f)] = true
+	}
+
+	var companions []string
+	visited := make(map[string]bool)
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		if visited[dir] {
+			continue
+		}
+		visited[dir] = true
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if seen[path] {
+				continue
+			}
+			if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			seen[path] = true
+			companions = append(companions, path)
+		}
+	}
+	verbose.Printf("--open-containing added %d files", len(companions))
+	return append(files, companions...)
+}
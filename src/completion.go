@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// flagName returns the command-line spelling of f, using a single dash
+// for one-letter shorthands.
+func flagName(f *flag.Flag) string {
+	if len(f.Name) == 1 {
+		return "-" + f.Name
+	}
+	return "--" + f.Name
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// writeCompletion prints a completion script for shell, generated from
+// the flags registered on fs so the two never drift.
+func writeCompletion(w io.Writer, fs *flag.FlagSet, shell string) error {
+	switch shell {
+	case "bash":
+		writeBashCompletion(w, fs)
+	case "zsh":
+		writeZshCompletion(w, fs)
+	default:
+		return fmt.Errorf("unsupported shell %q: must be bash or zsh", shell)
+	}
+	return nil
+}
+
+func writeBashCompletion(w io.Writer, fs *flag.FlagSet) {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, flagName(f))
+	})
+
+	var cmds []string
+	for _, c := range commands {
+		cmds = append(cmds, c.name)
+	}
+
+	fmt.Fprintf(w, `_pyvim() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=( $(compgen -W "%s" -- "$cur") )
+		return
+	fi
+	COMPREPLY=( $(compgen -d -- "$cur") $(compgen -f -X '!*.py' -- "$cur") )
+	if [[ $COMP_CWORD -eq 1 ]]; then
+		COMPREPLY+=( $(compgen -W "%s" -- "$cur") )
+	fi
+}
+complete -o filenames -F _pyvim pyvim
+`, strings.Join(names, " "), strings.Join(cmds, " "))
+}
+
+func writeZshCompletion(w io.Writer, fs *flag.FlagSet) {
+	escape := strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`, `"`, `\"`).Replace
+
+	// The first argument may also be a command name
+	var cmds []string
+	for _, c := range commands {
+		cmds = append(cmds, fmt.Sprintf(`%s\:"%s"`, c.name, escape(c.summary)))
+	}
+	fmt.Fprintln(w, "#compdef pyvim")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "_pyvim_first() {")
+	fmt.Fprintf(w, "\t_alternative 'commands:command:((%s))' 'files:file:_files -g \"*.py(-.)\"'\n", strings.Join(cmds, " "))
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "_arguments \\")
+	fs.VisitAll(func(f *flag.Flag) {
+		usage := escape(f.Usage)
+		if isBoolFlag(f) {
+			fmt.Fprintf(w, "\t'%s[%s]' \\\n", flagName(f), usage)
+		} else {
+			fmt.Fprintf(w, "\t'%s=[%s]:%s:' \\\n", flagName(f), usage, f.Name)
+		}
+	})
+	fmt.Fprintln(w, "\t'1: :_pyvim_first' \\")
+	fmt.Fprintln(w, "\t'*:file:_files -g \"*.py(-.)\"'")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const configName = ".pyvimrc"
+
+// findConfig returns the first .pyvimrc found in the working directory
+// or $HOME, or "" if there is none.
+func findConfig() string {
+	var dirs []string
+	if cwd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, cwd)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, home)
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, configName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadConfig seeds flag defaults on fs from key=value lines in the
+// config file. It must run before fs.Parse so the command line wins.
+func loadConfig(fs *flag.FlagSet) error {
+	path := findConfig()
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected key=value", path, n)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		// Keys for flags another command defines are skipped silently
+		if fs.Lookup(key) == nil {
+			if newFlagSet(&Options{}).Lookup(key) == nil {
+				warnf("%s:%d: unknown key %q", path, n, key)
+			}
+			continue
+		}
+		if err := fs.Set(key, value); err != nil {
+			return fmt.Errorf("%s:%d: invalid value for %s: %v", path, n, key, err)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// parseExtensions splits a comma-separated --ext value into a list of
+// extensions with a leading dot, such as ".py" and ".pyi".
+func parseExtensions(value string) ([]string, error) {
+	var exts []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		ext := strings.TrimPrefix(strings.TrimSpace(part), ".")
+		if ext == "" {
+			return nil, fmt.Errorf("invalid --ext %q: empty extension", value)
+		}
+		if strings.ContainsAny(ext, `./\*?[] `) {
+			return nil, fmt.Errorf("invalid --ext %q: malformed extension %q", value, part)
+		}
+		if !seen[ext] {
+			seen[ext] = true
+			exts = append(exts, "."+ext)
+		}
+	}
+	return exts, nil
+}
+
+// wantsFile reports whether the base name of path matches an --include
+// pattern or, when there are none, one of the --ext extensions. Patterns
+// must already be validated.
+func (o Options) wantsFile(path string) bool {
+	if len(o.includePatterns) == 0 {
+		return hasExtension(path, o.extensions)
+	}
+	base := filepath.Base(path)
+	for _, p := range o.includePatterns {
+		if ok, _ := filepath.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasExtension(path string, exts []string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// walkPyFiles collects every regular file below root accepted by
+// wantsFile, returning paths relative to root. Symlinked directories
+// are skipped unless following is enabled, in which case those pointing
+// outside root are walked and each real directory is visited once.
+// Directories deeper than maxDepth (when not negative) are not descended
+// into.
+func walkPyFiles(root string, opts Options) ([]string, error) {
+	var files []string
+	visited := make(map[string]bool)
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	realRoot, err = filepath.Abs(realRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var walk func(dir, prefix string) error
+	walk = func(dir, prefix string) error {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return err
+		}
+		real, err = filepath.Abs(real)
+		if err != nil {
+			return err
+		}
+
+		// Walk the resolved directory, since WalkDir does not descend
+		// into a symlinked root
+		return filepath.WalkDir(real, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(real, path)
+			if err != nil {
+				return err
+			}
+
+			// Visit each real directory at most once, within the depth limit
+			if d.IsDir() {
+				if visited[path] || tooDeep(filepath.Join(prefix, rel), opts.maxDepth) {
+					return filepath.SkipDir
+				}
+				visited[path] = true
+				return nil
+			}
+
+			if d.Type()&fs.ModeSymlink != 0 {
+				info, err := os.Stat(path)
+				if err != nil {
+					return nil
+				}
+				if info.IsDir() {
+					// Directories inside root are found under their real
+					// paths, which .gitignore rules are written for
+					if !opts.followSymlinks || insideDir(realRoot, path) {
+						return nil
+					}
+					return walk(path, filepath.Join(prefix, rel))
+				}
+				if !info.Mode().IsRegular() {
+					return nil
+				}
+			} else if !d.Type().IsRegular() {
+				return nil
+			}
+
+			if opts.wantsFile(path) {
+				files = append(files, filepath.Join(prefix, rel))
+			}
+			return nil
+		})
+	}
+
+	return files, walk(root, "")
+}
+
+// insideDir reports whether path, after resolving symlinks, is dir or
+// lies below it.
+func insideDir(dir, path string) bool {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, real)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// findPyFiles returns the regular files accepted by wantsFile directly
+// inside dir, or every such file below it when recursive is set. Paths
+// keep dir as prefix.
+func findPyFiles(dir string, opts Options) ([]string, error) {
+	if opts.recursive {
+		files, err := walkPyFiles(dir, opts)
+		if err != nil {
+			return nil, err
+		}
+		for i, f := range files {
+			files[i] = filepath.Join(dir, f)
+		}
+		return files, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !opts.wantsFile(path) {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// resolvePath joins name to root unless it is already absolute.
+func resolvePath(root, name string) string {
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(root, name)
+}
+
+// splitArgs separates positional arguments into directories to search
+// and files to open as-is, resolving them against root. Every argument
+// must exist.
+func splitArgs(root string, args []string) (dirs, files []string, err error) {
+	for _, arg := range args {
+		info, err := os.Stat(resolvePath(root, arg))
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("no such file or directory: %s", arg)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to stat %s: %v", arg, err)
+		}
+		if info.IsDir() {
+			dirs = append(dirs, arg)
+		} else {
+			files = append(files, arg)
+		}
+	}
+	return dirs, files, nil
+}
+
+// tooDeep reports whether the directory at rel, relative to the walk
+// root, lies more than maxDepth levels down. A negative maxDepth means
+// no limit.
+func tooDeep(rel string, maxDepth int) bool {
+	if maxDepth < 0 || rel == "." {
+		return false
+	}
+	return strings.Count(rel, string(filepath.Separator))+1 > maxDepth
+}
+
+// readFileList reads newline-separated paths from r, keeping those
+// accepted by want. Every kept path must exist relative to root.
+func readFileList(r io.Reader, root string, want func(string) bool) ([]string, error) {
+	var files []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		path := strings.TrimSpace(scanner.Text())
+		if path == "" || !want(path) {
+			continue
+		}
+		if _, err := os.Stat(resolvePath(root, path)); err != nil {
+			return nil, fmt.Errorf("no such file: %s", path)
+		}
+		files = append(files, path)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file list: %v", err)
+	}
+	return files, nil
+}
+
+// diffFiles checks the two --diff arguments, relative to root, which
+// bypass searching. Files not accepted by want are diffed anyway after a
+// warning.
+func diffFiles(root string, paths []string, want func(string) bool) ([]string, error) {
+	for _, path := range paths {
+		info, err := os.Stat(resolvePath(root, path))
+		if err != nil {
+			return nil, fmt.Errorf("no such file: %s", path)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("cannot diff directory %s", path)
+		}
+		if !want(path) {
+			warnf("%s is not a Python file", path)
+		}
+	}
+	return paths, nil
+}
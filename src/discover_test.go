@@ -0,0 +1,232 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTooDeep(t *testing.T) {
+	tests := []struct {
+		rel      string
+		maxDepth int
+		want     bool
+	}{
+		{".", 0, false},
+		{".", -1, false},
+		{"a", -1, false},
+		{"a/b/c/d", -1, false},
+		{"a", 0, true},
+		{"a", 1, false},
+		{"a/b", 1, true},
+		{"a/b", 2, false},
+		{"a/b/c", 2, true},
+	}
+	for _, tt := range tests {
+		if got := tooDeep(filepath.FromSlash(tt.rel), tt.maxDepth); got != tt.want {
+			t.Errorf("tooDeep(%q, %d) = %t, want %t", tt.rel, tt.maxDepth, got, tt.want)
+		}
+	}
+}
+
+func TestWalkPyFilesMaxDepth(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"top.py", "a/one.py", "a/b/two.py", "a/b/c/three.py", "a/notes.txt"} {
+		writeFile(t, filepath.Join(root, filepath.FromSlash(f)), "")
+	}
+
+	tests := []struct {
+		maxDepth int
+		want     []string
+	}{
+		{-1, []string{"a/b/c/three.py", "a/b/two.py", "a/one.py", "top.py"}},
+		{0, []string{"top.py"}},
+		{1, []string{"a/one.py", "top.py"}},
+		{2, []string{"a/b/two.py", "a/one.py", "top.py"}},
+	}
+	for _, tt := range tests {
+		opts := Options{recursive: true, maxDepth: tt.maxDepth, extensions: []string{".py"}}
+		got, err := walkPyFiles(root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, f := range got {
+			got[i] = filepath.ToSlash(f)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("walkPyFiles with maxDepth %d = %q, want %q", tt.maxDepth, got, tt.want)
+		}
+	}
+}
+
+func TestParseOptionsMaxDepth(t *testing.T) {
+	isolateConfig(t)
+	tests := []struct {
+		args      []string
+		maxDepth  int
+		recursive bool
+		wantErr   bool
+	}{
+		{nil, -1, false, false},
+		{[]string{"--max-depth", "0"}, 0, true, false},
+		{[]string{"--max-depth", "2"}, 2, true, false},
+		{[]string{"--max-depth", "2", "-r=false"}, 2, false, false},
+		{[]string{"--max-depth", "-1"}, 0, false, true},
+		{[]string{"--max-depth", "-3"}, 0, false, true},
+	}
+	for _, tt := range tests {
+		opts, err := parseOptions(tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseOptions(%q) succeeded, want an error", tt.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseOptions(%q): %v", tt.args, err)
+			continue
+		}
+		if opts.maxDepth != tt.maxDepth || opts.recursive != tt.recursive {
+			t.Errorf("parseOptions(%q) = max-depth %d, recursive %t; want %d, %t",
+				tt.args, opts.maxDepth, opts.recursive, tt.maxDepth, tt.recursive)
+		}
+	}
+}
+
+func TestDiscoverFiles(t *testing.T) {
+	root := newRepo(t, "/build/\n")
+	for _, f := range []string{"a.py", "b.py", "notes.txt", "build/gen.py", "sub/y.py", "sub/test_y.py"} {
+		writeFile(t, filepath.Join(root, filepath.FromSlash(f)), "")
+	}
+	other := newRepo(t, "skip.py\n")
+	for _, f := range []string{"c.py", "skip.py"} {
+		writeFile(t, filepath.Join(other, f), "")
+	}
+
+	target := gotoTarget{file: "sub/y.py", line: 1}
+	tests := []struct {
+		name  string
+		paths []string
+		setup func(*Options)
+		want  []string
+	}{
+		{"default", nil, nil, []string{"a.py", "b.py"}},
+		{"recursive", nil, func(o *Options) { o.recursive = true },
+			[]string{"a.py", "b.py", "sub/test_y.py", "sub/y.py"}},
+		{"no gitignore", nil, func(o *Options) { o.recursive, o.noGitignore = true, true },
+			[]string{"a.py", "b.py", "build/gen.py", "sub/test_y.py", "sub/y.py"}},
+		{"no tests", nil, func(o *Options) { o.recursive, o.noTests = true, true },
+			[]string{"a.py", "b.py", "sub/y.py"}},
+		{"exclude", nil, func(o *Options) { o.recursive, o.excludePatterns = true, stringList{"sub/*"} },
+			[]string{"a.py", "b.py"}},
+		{"dir and file", []string{"sub", "a.py", "./a.py"}, nil,
+			[]string{"a.py", "sub/test_y.py", "sub/y.py"}},
+		{"explicit file skips filters", []string{"build/gen.py"}, func(o *Options) { o.excludePatterns = stringList{"*gen*"} },
+			[]string{"build/gen.py"}},
+		{"absolute dir", []string{root}, func(o *Options) { o.recursive = true }, []string{
+			filepath.Join(root, "a.py"), filepath.Join(root, "b.py"),
+			filepath.Join(root, "sub", "test_y.py"), filepath.Join(root, "sub", "y.py"),
+		}},
+		{"other repository", []string{other}, nil, []string{filepath.Join(other, "c.py")}},
+		{"goto counts against max files", nil, func(o *Options) { o.recursive, o.gotoTarget, o.maxFiles = true, &target, 2 },
+			[]string{"sub/y.py", "a.py"}},
+		{"goto with first", nil, func(o *Options) { o.recursive, o.gotoTarget, o.first = true, &target, true },
+			[]string{"sub/y.py"}},
+		{"reverse", nil, func(o *Options) { o.reverse = true }, []string{"b.py", "a.py"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{paths: tt.paths, extensions: []string{".py"}, sortMode: "name", maxDepth: -1}
+			if tt.setup != nil {
+				tt.setup(&opts)
+			}
+			got, err := discoverFiles(root, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, f := range got {
+				if !filepath.IsAbs(f) {
+					got[i] = filepath.ToSlash(f)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("discoverFiles(%q) = %q, want %q", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverFilesErrors(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.py"), "")
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"missing path", Options{paths: []string{"nope"}}, "no such file or directory: nope"},
+		{"missing goto file", Options{gotoTarget: &gotoTarget{file: "nope.py", line: 1}}, "no such file: nope.py"},
+		{"goto not python", Options{gotoTarget: &gotoTarget{file: "a.txt", line: 1}}, "not a Python file"},
+		{"diff missing file", Options{diff: true, paths: []string{"a.py", "b.py"}}, "no such file: b.py"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.extensions, tt.opts.sortMode, tt.opts.maxDepth = []string{".py"}, "name", -1
+			_, err := discoverFiles(root, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("discoverFiles error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverFilesStdin(t *testing.T) {
+	root := newRepo(t, "/build/\n")
+	for _, f := range []string{"a.py", "build/gen.py", "sub/y.py", "sub/test_x.py"} {
+		writeFile(t, filepath.Join(root, filepath.FromSlash(f)), "")
+	}
+	list := filepath.Join(t.TempDir(), "list")
+	writeFile(t, list, "build/gen.py\nsub/test_x.py\nsub/y.py\nnotes.txt\na.py\n")
+
+	tests := []struct {
+		name  string
+		setup func(*Options)
+		want  []string
+	}{
+		{"gitignore", nil, []string{"a.py", "sub/test_x.py", "sub/y.py"}},
+		{"no gitignore", func(o *Options) { o.noGitignore = true }, []string{"a.py", "build/gen.py", "sub/test_x.py", "sub/y.py"}},
+		{"no tests", func(o *Options) { o.noTests = true }, []string{"a.py", "sub/y.py"}},
+		{"exclude", func(o *Options) { o.excludePatterns = stringList{"a.*"} }, []string{"sub/test_x.py", "sub/y.py"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(list)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			stdin := os.Stdin
+			os.Stdin = f
+			defer func() { os.Stdin = stdin }()
+
+			opts := Options{stdin: true, extensions: []string{".py"}, sortMode: "name", maxDepth: -1}
+			if tt.setup != nil {
+				tt.setup(&opts)
+			}
+			got, err := discoverFiles(root, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, f := range got {
+				got[i] = filepath.ToSlash(f)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("discoverFiles = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
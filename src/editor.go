@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// editorKind classifies the resolved editor so callers can pick
+// editor-specific arguments. Unknown editors get vim-style arguments.
+type editorKind int
+
+const (
+	editorUnknown editorKind = iota
+	editorVim
+	editorNeovim
+)
+
+func (k editorKind) String() string {
+	switch k {
+	case editorVim:
+		return "vim"
+	case editorNeovim:
+		return "neovim"
+	}
+	return "unknown"
+}
+
+// editorKinds caches detection results by editor path so --version is
+// run at most once per editor.
+var editorKinds = make(map[string]editorKind)
+
+// detectEditor runs editor --version and classifies it from the first
+// line of output.
+func detectEditor(editor string) editorKind {
+	if kind, ok := editorKinds[editor]; ok {
+		return kind
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, editor, "--version")
+	// Don't wait on output pipes held open by anything the editor spawned
+	cmd.WaitDelay = time.Second
+	out, err := cmd.Output()
+
+	kind := editorUnknown
+	if err == nil {
+		line, _ := bufio.NewReader(bytes.NewReader(out)).ReadString('\n')
+		switch {
+		case strings.HasPrefix(line, "NVIM"):
+			kind = editorNeovim
+		case strings.HasPrefix(line, "VIM"):
+			kind = editorVim
+		}
+	}
+
+	editorKinds[editor] = kind
+	return kind
+}
+
+// plainArgs returns the arguments that skip user configuration and
+// plugins. gvim also needs its separate gvimrc disabled.
+func plainArgs(editor string, kind editorKind) []string {
+	if kind == editorNeovim {
+		return []string{"-u", "NONE"}
+	}
+	args := []string{"-u", "NONE", "-N"}
+	if strings.HasPrefix(filepath.Base(editor), "gvim") {
+		args = append(args, "-U", "NONE")
+	}
+	return args
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// isTestFile reports whether the base name of path follows the pytest
+// and unittest naming conventions. Matching is case-sensitive.
+func isTestFile(path string) bool {
+	base := filepath.Base(path)
+	if base == "conftest.py" {
+		return true
+	}
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	return strings.HasPrefix(stem, "test_") || strings.HasSuffix(stem, "_test")
+}
+
+// filterTests drops test files and any file sharing a directory with a
+// conftest.py, since those directories hold test support code.
+func filterTests(files []string) []string {
+	conftest := make(map[string]bool)
+	hasConftest := func(dir string) bool {
+		if v, ok := conftest[dir]; ok {
+			return v
+		}
+		_, err := os.Stat(filepath.Join(dir, "conftest.py"))
+		conftest[dir] = err == nil
+		return conftest[dir]
+	}
+
+	var kept []string
+	for _, f := range files {
+		if isTestFile(f) || hasConftest(filepath.Dir(f)) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// dedupe removes repeated paths, keeping the first occurrence.
+func dedupe(files []string) []string {
+	seen := make(map[string]bool, len(files))
+	var kept []string
+	for _, f := range files {
+		clean := filepath.Clean(f)
+		if seen[clean] {
+			continue
+		}
+		seen[clean] = true
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// validatePatterns reports the first syntactically invalid pattern given
+// to the named flag, so a bad pattern aborts the run before the editor
+// is launched.
+func validatePatterns(name string, patterns []string) error {
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid --%s pattern %q: %v", name, p, err)
+		}
+	}
+	return nil
+}
+
+// filterExcluded drops files whose base name or path, as returned by
+// name, matches any of patterns. Patterns must already be validated.
+func filterExcluded(files, patterns []string, name func(string) string) []string {
+	var kept []string
+	for _, f := range files {
+		if !matchesAny(name(f), patterns) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+func matchesAny(path string, patterns []string) bool {
+	clean := filepath.Clean(path)
+	base := filepath.Base(clean)
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, base); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, clean); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func samePath(a, b string) bool {
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// dropMissing warns about and removes files that can no longer be
+// stat'ed, such as ones deleted since discovery.
+func dropMissing(files []string) []string {
+	var kept []string
+	for _, f := range files {
+		if _, err := os.Stat(f); err != nil {
+			warnf("skipping %s: %v", f, err)
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// filterSince keeps files modified after cutoff. Files that cannot be
+// stat'ed are warned about and dropped.
+func filterSince(files []string, cutoff time.Time) []string {
+	var kept []string
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			warnf("skipping %s: %v", f, err)
+			continue
+		}
+		if !info.ModTime().Before(cutoff) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// filterPackages keeps files that live in a Python package, that is a
+// directory containing __init__.py. If no file is in a package, files is
+// returned unchanged with ok set to false.
+func filterPackages(files []string) (kept []string, ok bool) {
+	isPackage := make(map[string]bool)
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		pkg, seen := isPackage[dir]
+		if !seen {
+			_, err := os.Stat(filepath.Join(dir, "__init__.py"))
+			pkg = err == nil
+			isPackage[dir] = pkg
+		}
+		if pkg {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == 0 {
+		return files, false
+	}
+	return kept, true
+}
+
+// groupByDir reorders files so those sharing a directory are adjacent,
+// keeping directories in order of first appearance.
+func groupByDir(files []string) []string {
+	var dirs []string
+	byDir := make(map[string][]string)
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		if _, seen := byDir[dir]; !seen {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], f)
+	}
+
+	grouped := make([]string, 0, len(files))
+	for _, dir := range dirs {
+		grouped = append(grouped, byDir[dir]...)
+	}
+	return grouped
+}
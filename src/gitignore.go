@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a single parsed line from a .gitignore file.
+type ignoreRule struct {
+	base     string // directory containing the .gitignore
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// gitignore holds the rules from every .gitignore that applies to a
+// directory, ordered so that later rules take precedence.
+type gitignore struct {
+	rules []ignoreRule
+}
+
+// loadGitignore reads the repository root .gitignore and the nearest
+// .gitignore at or above dir. A missing file contributes no rules.
+func loadGitignore(dir string) (*gitignore, error) {
+	g := &gitignore{}
+
+	root := findRepoRoot(dir)
+	if root != "" {
+		if err := g.parseFile(root); err != nil {
+			return nil, err
+		}
+	}
+
+	// Look for the nearest .gitignore, stopping at the repository root
+	for d := dir; d != root; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".gitignore")); err == nil {
+			if err := g.parseFile(d); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if root == "" || d == filepath.Dir(d) {
+			break
+		}
+	}
+
+	return g, nil
+}
+
+// findRepoRoot returns the closest ancestor of dir containing a .git
+// entry, or "" if dir is not inside a repository.
+func findRepoRoot(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		if d == filepath.Dir(d) {
+			return ""
+		}
+	}
+}
+
+func (g *gitignore) parseFile(dir string) error {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: dir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		g.rules = append(g.rules, rule)
+	}
+	return scanner.Err()
+}
+
+// ignored reports whether the absolute path p is excluded. A file is
+// excluded if it or any of its parent directories is matched.
+func (g *gitignore) ignored(p string) bool {
+	if len(g.rules) == 0 {
+		return false
+	}
+
+	// Check each parent directory from the top down, then the file itself
+	dir := filepath.Dir(p)
+	var parents []string
+	for d := dir; d != filepath.Dir(d); d = filepath.Dir(d) {
+		parents = append([]string{d}, parents...)
+	}
+	for _, d := range parents {
+		if g.match(d, true) {
+			return true
+		}
+	}
+	return g.match(p, false)
+}
+
+// match applies every rule to p, letting the last matching rule decide.
+func (g *gitignore) match(p string, isDir bool) bool {
+	matched := false
+	for _, rule := range g.rules {
+		rel, err := filepath.Rel(rule.base, p)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if rule.matches(filepath.ToSlash(rel), isDir) {
+			matched = !rule.negate
+		}
+	}
+	return matched
+}
+
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		return matchSegments(strings.Split(r.pattern, "/"), strings.Split(rel, "/"))
+	}
+	ok, _ := path.Match(r.pattern, path.Base(rel))
+	return ok
+}
+
+// matchSegments matches slash-separated path segments, treating "**"
+// as zero or more segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// filter drops every path in files that is ignored. Relative paths are
+// taken relative to dir.
+func (g *gitignore) filter(dir string, files []string) []string {
+	var kept []string
+	for _, f := range files {
+		path := f
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, f)
+		}
+		if !g.ignored(path) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGitignore(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		path    string
+		ignored bool
+	}{
+		{"plain pattern", "*.pyc\n", "mod.pyc", true},
+		{"plain pattern in subdir", "*.pyc\n", "pkg/mod.pyc", true},
+		{"no match", "*.pyc\n", "mod.py", false},
+		{"comment", "# mod.py\n", "mod.py", false},
+		{"escaped hash", "\\#mod.py\n", "#mod.py", true},
+		{"trailing space", "mod.py  \n", "mod.py", true},
+		{"negated", "*.py\n!keep.py\n", "keep.py", false},
+		{"negated other file", "*.py\n!keep.py\n", "drop.py", true},
+		{"negation then re-ignored", "*.py\n!keep.py\nkeep.py\n", "keep.py", true},
+		{"negation under ignored dir", "build/\n!build/keep.py\n", "build/keep.py", true},
+		{"dir only matches dir", "build/\n", "build/out.py", true},
+		{"dir only skips file", "build/\n", "build", false},
+		{"dir only nested", "build/\n", "pkg/build/out.py", true},
+		{"anchored root", "/top.py\n", "top.py", true},
+		{"anchored not nested", "/top.py\n", "pkg/top.py", false},
+		{"anchored path", "pkg/gen.py\n", "pkg/gen.py", true},
+		{"anchored path elsewhere", "pkg/gen.py\n", "other/pkg/gen.py", false},
+		{"double star", "**/gen/*.py\n", "a/b/gen/x.py", true},
+		{"double star at root", "**/gen/*.py\n", "gen/x.py", true},
+		{"double star middle", "a/**/x.py\n", "a/b/c/x.py", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newRepo(t, tt.rules)
+			g, err := loadGitignore(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.ignored(filepath.Join(root, filepath.FromSlash(tt.path))); got != tt.ignored {
+				t.Errorf("ignored(%q) with rules %q = %t, want %t", tt.path, tt.rules, got, tt.ignored)
+			}
+		})
+	}
+}
+
+func TestGitignoreMissing(t *testing.T) {
+	root := t.TempDir()
+	g, err := loadGitignore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.ignored(filepath.Join(root, "mod.py")) {
+		t.Error("file ignored without a .gitignore")
+	}
+
+	files := []string{"a.py", "pkg/b.py"}
+	if got := g.filter(root, files); len(got) != len(files) {
+		t.Errorf("filter = %q, want %q", got, files)
+	}
+}
+
+func TestGitignoreNearest(t *testing.T) {
+	root := newRepo(t, "*.pyc\n")
+	sub := filepath.Join(root, "pkg")
+	writeFile(t, filepath.Join(sub, ".gitignore"), "gen.py\n")
+
+	g, err := loadGitignore(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := g.filter(sub, []string{"gen.py", "mod.pyc", "mod.py", "other/gen.py"})
+	if len(got) != 1 || got[0] != "mod.py" {
+		t.Errorf("filter = %q, want [mod.py]", got)
+	}
+}
+
+func TestGitignoreAbsolutePaths(t *testing.T) {
+	root := newRepo(t, "/build/\n*.pyc\n")
+	g, err := loadGitignore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Files found under an absolute directory argument are absolute, and
+	// must not be joined to the working directory again
+	files := []string{
+		filepath.Join(root, "build", "gen.py"),
+		filepath.Join(root, "pkg", "build", "gen.py"),
+		filepath.Join(root, "mod.pyc"),
+		filepath.Join(root, "mod.py"),
+	}
+	want := []string{files[1], files[3]}
+	if got := g.filter(t.TempDir(), files); !slices.Equal(got, want) {
+		t.Errorf("filter = %q, want %q", got, want)
+	}
+}
+
+func TestGitignoreOtherRepo(t *testing.T) {
+	here := newRepo(t, "*.py\n")
+	other := newRepo(t, "gen.py\n")
+
+	// Rules come from the repository holding the searched directory
+	g, err := loadGitignore(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{filepath.Join(other, "gen.py"), filepath.Join(other, "mod.py")}
+	if got := g.filter(here, files); !slices.Equal(got, files[1:]) {
+		t.Errorf("filter = %q, want %q", got, files[1:])
+	}
+}
+
+// newRepo creates a git repository whose root .gitignore holds rules.
+func newRepo(t *testing.T, rules string) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, ".gitignore"), rules)
+	return root
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
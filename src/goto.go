@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// gotoTarget is a parsed --goto location.
+type gotoTarget struct {
+	file string
+	line int
+	col  int
+}
+
+// parseGoto parses "file:line" or "file:line:col". The numbers are
+// taken from the right so file names may themselves contain colons.
+func parseGoto(value string) (gotoTarget, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 {
+		return gotoTarget{}, fmt.Errorf("invalid --goto %q: expected file:line or file:line:col", value)
+	}
+
+	var nums []int
+	for len(parts) > 1 && len(nums) < 2 {
+		n, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			break
+		}
+		if n < 1 {
+			return gotoTarget{}, fmt.Errorf("invalid --goto %q: line and column must be positive", value)
+		}
+		nums = append([]int{n}, nums...)
+		parts = parts[:len(parts)-1]
+	}
+	if len(nums) == 0 {
+		return gotoTarget{}, fmt.Errorf("invalid --goto %q: expected file:line or file:line:col", value)
+	}
+
+	target := gotoTarget{file: strings.Join(parts, ":"), line: nums[0]}
+	if len(nums) == 2 {
+		target.col = nums[1]
+	}
+	if target.file == "" {
+		return gotoTarget{}, fmt.Errorf("invalid --goto %q: missing file name", value)
+	}
+	return target, nil
+}
+
+// validate checks that the target file exists relative to root and is
+// accepted by want.
+func (t gotoTarget) validate(root string, want func(string) bool) error {
+	if !want(t.file) {
+		return fmt.Errorf("invalid --goto file %s: not a Python file", t.file)
+	}
+	if _, err := os.Stat(resolvePath(root, t.file)); err != nil {
+		return fmt.Errorf("no such file: %s", t.file)
+	}
+	return nil
+}
+
+// args returns the editor arguments that place the cursor at the target
+// once the first file is loaded.
+func (t gotoTarget) args() []string {
+	args := []string{fmt.Sprintf("+%d", t.line)}
+	if t.col > 0 {
+		args = append(args, "-c", fmt.Sprintf("normal! %d|", t.col))
+	}
+	return args
+}
+
+// moveToFront returns files with target first, adding it if missing.
+func moveToFront(files []string, target string) []string {
+	out := []string{target}
+	for _, f := range files {
+		if !samePath(f, target) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
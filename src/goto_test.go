@@ -0,0 +1,89 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseGoto(t *testing.T) {
+	tests := []struct {
+		in   string
+		want gotoTarget
+	}{
+		{"a.py:3", gotoTarget{file: "a.py", line: 3}},
+		{"a.py:3:7", gotoTarget{file: "a.py", line: 3, col: 7}},
+		{"pkg/mod.py:120", gotoTarget{file: "pkg/mod.py", line: 120}},
+		{"odd:name.py:2", gotoTarget{file: "odd:name.py", line: 2}},
+		{"odd:name.py:2:4", gotoTarget{file: "odd:name.py", line: 2, col: 4}},
+		{"v2:1:2:3", gotoTarget{file: "v2:1", line: 2, col: 3}},
+		{"a.py:x:3", gotoTarget{file: "a.py:x", line: 3}},
+	}
+	for _, tt := range tests {
+		got, err := parseGoto(tt.in)
+		if err != nil {
+			t.Errorf("parseGoto(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseGoto(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseGotoErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"a.py", "expected file:line"},
+		{"", "expected file:line"},
+		{"a.py:", "expected file:line"},
+		{"a.py:x", "expected file:line"},
+		{"a.py:0", "must be positive"},
+		{"a.py:3:0", "must be positive"},
+		{"a.py:-2", "must be positive"},
+		{":3", "missing file name"},
+		{":3:4", "missing file name"},
+	}
+	for _, tt := range tests {
+		_, err := parseGoto(tt.in)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseGoto(%q) error = %v, want it to mention %q", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestGotoArgs(t *testing.T) {
+	tests := []struct {
+		target gotoTarget
+		want   []string
+	}{
+		{gotoTarget{file: "a.py", line: 3}, []string{"+3"}},
+		{gotoTarget{file: "a.py", line: 3, col: 7}, []string{"+3", "-c", "normal! 7|"}},
+	}
+	for _, tt := range tests {
+		if got := tt.target.args(); !slices.Equal(got, tt.want) {
+			t.Errorf("%+v.args() = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestMoveToFront(t *testing.T) {
+	tests := []struct {
+		files  []string
+		target string
+		want   []string
+	}{
+		{[]string{"a.py", "b.py", "c.py"}, "c.py", []string{"c.py", "a.py", "b.py"}},
+		{[]string{"a.py", "b.py"}, "a.py", []string{"a.py", "b.py"}},
+		{[]string{"a.py", "pkg/b.py"}, "./pkg/b.py", []string{"./pkg/b.py", "a.py"}},
+		{[]string{"a.py"}, "new.py", []string{"new.py", "a.py"}},
+		{nil, "new.py", []string{"new.py"}},
+	}
+	for _, tt := range tests {
+		if got := moveToFront(tt.files, tt.target); !slices.Equal(got, tt.want) {
+			t.Errorf("moveToFront(%q, %q) = %q, want %q", tt.files, tt.target, got, tt.want)
+		}
+	}
+}
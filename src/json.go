@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+)
+
+type jsonFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modtime"`
+}
+
+type jsonReport struct {
+	Editor string     `json:"editor"`
+	Python string     `json:"python"`
+	Root   string     `json:"root"`
+	Files  []jsonFile `json:"files"`
+}
+
+// writeJSON prints the resolved run as a JSON document. Files that can
+// no longer be stat'ed are warned about on stderr and left out.
+func writeJSON(w io.Writer, editor, python, root string, files []string) error {
+	report := jsonReport{
+		Editor: editor,
+		Python: python,
+		Root:   root,
+		Files:  []jsonFile{},
+	}
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			warnf("cannot stat %s: %v", f, err)
+			continue
+		}
+		report.Files = append(report.Files, jsonFile{
+			Path:    f,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Options holds the settings for a single run, parsed and validated
+// from the command line by parseOptions.
+type Options struct {
+	editor          string
+	pythonCmd       string
+	recursive       bool
+	followSymlinks  bool
+	noGitignore     bool
+	noTests         bool
+	noVenv          bool
+	excludePatterns stringList
+	includePatterns stringList
+	extensions      []string
+	sortMode        string
+	maxFiles        int
+	maxDepth        int
+	gotoTarget      *gotoTarget
+	sessionPath     string
+	chdir           string
+	timeout         time.Duration
+	since           time.Duration
+	changedBase     string
+	companions      []string
+
+	// extSpec, gotoSpec and companionsSpec are the raw --ext, --goto and
+	// --companions values, resolved into extensions, gotoTarget and
+	// companions
+	extSpec        string
+	gotoSpec       string
+	companionsSpec string
+
+	// paths are the positional file and directory arguments, and
+	// extraArgs the arguments after a literal "--"
+	paths     []string
+	extraArgs []string
+
+	stdin      bool
+	tabs       bool
+	view       bool
+	dryRun     bool
+	json       bool
+	count      bool
+	diff       bool
+	changed    bool
+	plain      bool
+	first      bool
+	reverse    bool
+	resume     bool
+	pick       bool
+	byPkg      bool
+	check      bool
+	checkOnly  bool
+	openCont   bool
+	allowEmpty bool
+
+	verbose bool
+	quiet   bool
+	version bool
+
+	// venv is the detected virtualenv and editorKind the detected
+	// editor flavour, both set by handlePyVim
+	venv       string
+	editorKind editorKind
+}
+
+// launches reports whether the run ends by starting the editor rather
+// than printing information.
+func (o Options) launches() bool {
+	return !o.dryRun && !o.count && !o.json && !o.checkOnly
+}
+
+// stringList is a flag.Value collecting every occurrence of a
+// repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// addDiscoveryFlags defines the flags that select and report files,
+// shared by the edit and list commands.
+func addDiscoveryFlags(fs *flag.FlagSet, opts *Options) {
+	fs.BoolVar(&opts.verbose, "verbose", false, "log discovery decisions to stderr")
+	fs.BoolVar(&opts.verbose, "v", false, "shorthand for --verbose")
+	fs.BoolVar(&opts.quiet, "quiet", false, "suppress informational messages")
+	fs.BoolVar(&opts.quiet, "q", false, "shorthand for --quiet")
+	fs.StringVar(&opts.chdir, "chdir", "", "run as if started in `dir`")
+	fs.StringVar(&opts.editor, "editor", "", "editor to launch (defaults to $EDITOR, then vim)")
+	fs.BoolVar(&opts.recursive, "recursive", false, "search subdirectories for Python files")
+	fs.BoolVar(&opts.recursive, "r", false, "shorthand for --recursive")
+	fs.IntVar(&opts.maxDepth, "max-depth", -1, "descend at most `N` directory levels (0 is the root only); implies --recursive unless it is set explicitly")
+	fs.BoolVar(&opts.followSymlinks, "follow-symlinks", false, "descend into symlinked directories when recursing")
+	fs.BoolVar(&opts.noGitignore, "no-gitignore", false, "include files matched by .gitignore")
+	fs.StringVar(&opts.extSpec, "ext", "py", "comma-separated list of file extensions to open")
+	fs.Var(&opts.includePatterns, "include", "open files whose name matches `glob` instead of using --ext (repeatable)")
+	fs.BoolVar(&opts.allowEmpty, "allow-empty", false, "exit successfully without launching the editor when no files match")
+	fs.BoolVar(&opts.openCont, "open-containing", false, "also open companion files, such as README.md, from each directory with a matching file")
+	fs.StringVar(&opts.companionsSpec, "companions", defaultCompanions, "comma-separated list of companion files for --open-containing")
+	fs.Var(&opts.excludePatterns, "exclude", "skip files matching `pattern` (repeatable)")
+	fs.BoolVar(&opts.changed, "changed", false, "only open files modified or added according to git status")
+	fs.StringVar(&opts.changedBase, "changed-base", "", "with --changed, compare against `ref` instead of the working tree")
+	fs.DurationVar(&opts.since, "since", 0, "only open files modified within `duration`, such as 2h or 30m")
+	fs.BoolVar(&opts.byPkg, "by-package", false, "only open files in directories with an __init__.py, grouped by package")
+	fs.BoolVar(&opts.noTests, "no-tests", false, "skip test_*.py, *_test.py, and conftest.py directories")
+	fs.StringVar(&opts.gotoSpec, "goto", "", "open `file:line[:col]` first with the cursor at that position")
+	fs.BoolVar(&opts.resume, "resume", false, "reopen the files from the last session in this directory")
+	fs.BoolVar(&opts.stdin, "stdin", false, "read file paths from standard input instead of searching")
+	fs.BoolVar(&opts.count, "count", false, "print the number of matching files and exit")
+	fs.BoolVar(&opts.json, "json", false, "print the resolved files as JSON and exit")
+	fs.StringVar(&opts.sortMode, "sort", "name", "order files by name, mtime, or size")
+	fs.BoolVar(&opts.reverse, "reverse", false, "reverse the sort order, applied before --max-files")
+	fs.BoolVar(&opts.first, "first", false, "open only the most recently modified file")
+	fs.IntVar(&opts.maxFiles, "max-files", 0, "open at most `N` files (0 means unlimited)")
+	fs.BoolVar(&opts.noVenv, "no-venv", false, "do not activate a .venv or venv in the working directory")
+	fs.StringVar(&opts.pythonCmd, "python", "", "python interpreter to require (defaults to python3, then python)")
+}
+
+// addLaunchFlags defines the flags that only affect starting the editor.
+func addLaunchFlags(fs *flag.FlagSet, opts *Options) {
+	fs.BoolVar(&opts.pick, "pick", false, "choose files interactively with fzf or a numbered prompt instead of truncating at --max-files")
+	fs.BoolVar(&opts.check, "check", false, "report files python cannot compile before opening them")
+	fs.BoolVar(&opts.checkOnly, "check-only", false, "report files python cannot compile and exit, failing if any do")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "print the files that would be opened and exit")
+	fs.StringVar(&opts.sessionPath, "session", "", "restore the vim session at `path`, or save one there on exit")
+	fs.BoolVar(&opts.plain, "plain", false, "start the editor without user configuration or plugins")
+	fs.BoolVar(&opts.diff, "diff", false, "open exactly two files side by side in diff mode")
+	fs.BoolVar(&opts.view, "view", false, "open files read-only")
+	fs.BoolVar(&opts.tabs, "tabs", false, "open each file in its own tab")
+	fs.BoolVar(&opts.tabs, "p", false, "shorthand for --tabs")
+	fs.DurationVar(&opts.timeout, "timeout", 0, "kill the editor after `duration` (0 means no timeout)")
+}
+
+// newFlagSet defines every flag of the edit command, storing values in
+// opts. Completion scripts are generated from the same definitions.
+func newFlagSet(opts *Options) *flag.FlagSet {
+	fs := flag.NewFlagSet("pyvim", flag.ContinueOnError)
+	fs.BoolVar(&opts.version, "version", false, "print version information and exit")
+	addDiscoveryFlags(fs, opts)
+	addLaunchFlags(fs, opts)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: pyvim [command] [flags] [paths...] [-- editor args...]\n\ncommands:\n")
+		for _, c := range commands {
+			fmt.Fprintf(fs.Output(), "  %-12s %s\n", c.name, c.summary)
+		}
+		fmt.Fprintf(fs.Output(), "\nflags:\n")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// newListFlagSet defines the flags of the list command.
+func newListFlagSet(opts *Options) *flag.FlagSet {
+	fs := flag.NewFlagSet("pyvim list", flag.ContinueOnError)
+	addDiscoveryFlags(fs, opts)
+	return fs
+}
+
+// parseOptions parses the edit command's args (without the program
+// name), seeding defaults from .pyvimrc and PYVIM_DEFAULT_ARGS, and
+// validates the result.
+func parseOptions(args []string) (Options, error) {
+	defaults, err := defaultArgs()
+	if err != nil {
+		return Options{}, err
+	}
+	var opts Options
+	if err := parseFlags(newFlagSet(&opts), &opts, defaults, args); err != nil {
+		return Options{}, err
+	}
+	return opts, nil
+}
+
+// parseFlags parses defaults and then args with fs, whose flags store
+// into opts, and validates the result. Flags in args override those in
+// defaults, while the paths and editor arguments of both are kept,
+// defaults first.
+func parseFlags(fs *flag.FlagSet, opts *Options, defaults, args []string) error {
+	if err := loadConfig(fs); err != nil {
+		return err
+	}
+
+	defaultFlags, defaultExtra := splitExtra(defaults)
+	if err := fs.Parse(defaultFlags); err != nil {
+		return fmt.Errorf("invalid %s: %v", defaultArgsEnv, err)
+	}
+	defaultPaths := fs.Args()
+
+	flagArgs, extra := splitExtra(args)
+	if err := fs.Parse(flagArgs); err != nil {
+		return usageError{err}
+	}
+	opts.paths = slices.Concat(defaultPaths, fs.Args())
+	opts.extraArgs = slices.Concat(defaultExtra, extra)
+	if opts.version {
+		return nil
+	}
+
+	if err := validateSort(opts.sortMode); err != nil {
+		return err
+	}
+	exts, err := parseExtensions(opts.extSpec)
+	if err != nil {
+		return err
+	}
+	opts.extensions = exts
+	companions, err := parseCompanions(opts.companionsSpec)
+	if err != nil {
+		return err
+	}
+	opts.companions = companions
+	if err := validatePatterns("exclude", opts.excludePatterns); err != nil {
+		return err
+	}
+	if err := validatePatterns("include", opts.includePatterns); err != nil {
+		return err
+	}
+	if opts.maxFiles < 0 {
+		return fmt.Errorf("invalid --max-files %d: must not be negative", opts.maxFiles)
+	}
+	// The -1 default means unlimited but cannot be given explicitly
+	if flagSet(fs, "max-depth") && opts.maxDepth < 0 {
+		return fmt.Errorf("invalid --max-depth %d: must not be negative", opts.maxDepth)
+	}
+	if opts.changedBase != "" {
+		opts.changed = true
+	}
+
+	// --max-depth implies --recursive unless recursion was set explicitly
+	if opts.maxDepth >= 0 && !flagSet(fs, "recursive", "r") {
+		opts.recursive = true
+	}
+	if opts.first && opts.maxFiles > 0 {
+		return fmt.Errorf("--first and --max-files cannot be used together")
+	}
+	if opts.since < 0 {
+		return fmt.Errorf("invalid --since %s: must not be negative", opts.since)
+	}
+	if opts.timeout < 0 {
+		return fmt.Errorf("invalid --timeout %s: must not be negative", opts.timeout)
+	}
+	if opts.diff && len(opts.paths) != 2 {
+		return fmt.Errorf("--diff requires exactly two files, got %d", len(opts.paths))
+	}
+	if opts.gotoSpec != "" {
+		// These open files chosen elsewhere, so the cursor would land in
+		// some other file
+		switch {
+		case opts.diff:
+			return fmt.Errorf("--goto cannot be used with --diff")
+		case opts.resume:
+			return fmt.Errorf("--goto cannot be used with --resume")
+		case opts.sessionPath != "" && sessionExists(opts.sessionPath):
+			return fmt.Errorf("--goto cannot be used when --session restores %s", opts.sessionPath)
+		}
+		target, err := parseGoto(opts.gotoSpec)
+		if err != nil {
+			return err
+		}
+		opts.gotoTarget = &target
+	}
+
+	return nil
+}
+
+// usageError is a command-line error that the FlagSet has already
+// printed along with its usage.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// flagSet reports whether any of names was set on fs, either on the
+// command line or from the config file.
+func flagSet(fs *flag.FlagSet, names ...string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		for _, name := range names {
+			if f.Name == name {
+				set = true
+			}
+		}
+	})
+	return set
+}
+
+// keepFlags drops the flags in args that fs does not define, leaving
+// unknown flags for fs to reject and the arguments after the flags
+// untouched. all defines every flag args may use, telling whether a
+// dropped flag takes a value.
+func keepFlags(args []string, fs, all *flag.FlagSet) []string {
+	var kept []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if len(a) < 2 || a[0] != '-' || a == "--" {
+			return append(kept, args[i:]...)
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		n := 1
+		if f := all.Lookup(name); f != nil && !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+			n = 2
+		}
+		if fs.Lookup(name) != nil || all.Lookup(name) == nil {
+			kept = append(kept, args[i:i+n]...)
+		}
+		i += n - 1
+	}
+	return kept
+}
+
+// splitExtra separates the arguments after the first literal "--" so
+// flag parsing never sees them.
+func splitExtra(args []string) ([]string, []string) {
+	for i, a := range args {
+		if a == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// isolateConfig hides the developer's .pyvimrc files and
+// PYVIM_DEFAULT_ARGS from tests that parse options.
+func isolateConfig(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(defaultArgsEnv, "")
+	t.Chdir(t.TempDir())
+}
+
+func TestParseOptionsErrors(t *testing.T) {
+	isolateConfig(t)
+	tests := []struct {
+		args  []string
+		want  string
+		usage bool
+	}{
+		{[]string{"--bogus"}, "flag provided but not defined", true},
+		{[]string{"--max-files", "many"}, "invalid value", true},
+		{[]string{"--sort", "random"}, "random", false},
+		{[]string{"--max-files", "-1"}, "must not be negative", false},
+		{[]string{"--first", "--max-files", "2"}, "cannot be used together", false},
+		{[]string{"--goto", "a.py"}, "expected file:line", false},
+	}
+	for _, tt := range tests {
+		_, err := parseOptions(tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseOptions(%q) error = %v, want it to mention %q", tt.args, err, tt.want)
+			continue
+		}
+		var usageErr usageError
+		if got := errors.As(err, &usageErr); got != tt.usage {
+			t.Errorf("parseOptions(%q) usage error = %t, want %t", tt.args, got, tt.usage)
+		}
+	}
+}
+
+func TestParseOptionsHelp(t *testing.T) {
+	isolateConfig(t)
+	if _, err := parseOptions([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("parseOptions(-h) error = %v, want flag.ErrHelp", err)
+	}
+}
+
+func TestParseOptionsDefaults(t *testing.T) {
+	isolateConfig(t)
+	tests := []struct {
+		defaults     string
+		args         []string
+		paths, extra []string
+		sortMode     string
+		dryRun       bool
+	}{
+		{"--sort size", []string{"--sort", "mtime"}, nil, nil, "mtime", false},
+		{"--sort size", nil, nil, nil, "size", false},
+		{"pkg", []string{"--dry-run"}, []string{"pkg"}, nil, "name", true},
+		{"pkg", []string{"--dry-run", "a.py"}, []string{"pkg", "a.py"}, nil, "name", true},
+		{`-- -c "set nu"`, []string{"-r", "--dry-run"}, nil, []string{"-c", "set nu"}, "name", true},
+		{`-- -c "set nu"`, []string{"a.py", "--", "-R"}, []string{"a.py"}, []string{"-c", "set nu", "-R"}, "name", false},
+	}
+	for _, tt := range tests {
+		t.Setenv(defaultArgsEnv, tt.defaults)
+		opts, err := parseOptions(tt.args)
+		if err != nil {
+			t.Errorf("parseOptions(%q) with defaults %q: %v", tt.args, tt.defaults, err)
+			continue
+		}
+		if !slices.Equal(opts.paths, tt.paths) || !slices.Equal(opts.extraArgs, tt.extra) ||
+			opts.sortMode != tt.sortMode || opts.dryRun != tt.dryRun {
+			t.Errorf("parseOptions(%q) with defaults %q = paths %q, extra %q, sort %s, dry-run %t; want %q, %q, %s, %t",
+				tt.args, tt.defaults, opts.paths, opts.extraArgs, opts.sortMode, opts.dryRun,
+				tt.paths, tt.extra, tt.sortMode, tt.dryRun)
+		}
+	}
+}
+
+func TestKeepFlags(t *testing.T) {
+	all := newFlagSet(&Options{})
+	list := newListFlagSet(&Options{})
+	tests := []struct {
+		args, want []string
+	}{
+		{nil, nil},
+		{[]string{"--tabs", "-r"}, []string{"-r"}},
+		{[]string{"--timeout", "5s", "--sort", "size"}, []string{"--sort", "size"}},
+		{[]string{"--timeout=5s", "--exclude=*_pb2.py"}, []string{"--exclude=*_pb2.py"}},
+		{[]string{"--bogus", "-r"}, []string{"--bogus", "-r"}},
+		{[]string{"-r", "pkg", "--tabs"}, []string{"-r", "pkg", "--tabs"}},
+		{[]string{"--view", "--", "-c", "set nu"}, []string{"--", "-c", "set nu"}},
+	}
+	for _, tt := range tests {
+		if got := keepFlags(tt.args, list, all); !slices.Equal(got, tt.want) {
+			t.Errorf("keepFlags(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseOptionsGotoConflicts(t *testing.T) {
+	isolateConfig(t)
+	session := filepath.Join(t.TempDir(), "session.vim")
+	if err := os.WriteFile(session, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	newSession := filepath.Join(t.TempDir(), "new.vim")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--goto", "a.py:3", "--diff", "a.py", "b.py"}, "--goto cannot be used with --diff"},
+		{[]string{"--goto", "a.py:3", "--resume"}, "--goto cannot be used with --resume"},
+		{[]string{"--goto", "a.py:3", "--session", session}, "--goto cannot be used when --session restores"},
+		{[]string{"--goto", "a.py:3", "--session", newSession}, ""},
+	}
+	for _, tt := range tests {
+		_, err := parseOptions(tt.args)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("parseOptions(%q): %v", tt.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseOptions(%q) error = %v, want it to mention %q", tt.args, err, tt.want)
+		}
+	}
+}
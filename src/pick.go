@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// errPickCancelled is returned when the user makes no selection.
+var errPickCancelled = errors.New("no files selected")
+
+// pickFiles lets the user choose among files with fzf when it is on
+// PATH, or a numbered prompt on /dev/tty otherwise. The selection keeps
+// the order of files.
+func pickFiles(files []string) ([]string, error) {
+	var chosen map[string]bool
+	var err error
+	if path, lookErr := exec.LookPath("fzf"); lookErr == nil {
+		chosen, err = pickWithFzf(path, files)
+	} else {
+		chosen, err = pickWithPrompt(files)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var picked []string
+	for _, f := range files {
+		if chosen[f] {
+			picked = append(picked, f)
+		}
+	}
+	if len(picked) == 0 {
+		return nil, errPickCancelled
+	}
+	return picked, nil
+}
+
+func pickWithFzf(fzf string, files []string) (map[string]bool, error) {
+	cmd := exec.Command(fzf, "--multi")
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\n") + "\n")
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, errPickCancelled
+		}
+		return nil, fmt.Errorf("failed to run fzf: %v", err)
+	}
+
+	chosen := make(map[string]bool)
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			chosen[line] = true
+		}
+	}
+	return chosen, nil
+}
+
+func pickWithPrompt(files []string) (map[string]bool, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open /dev/tty for --pick: %v", err)
+	}
+	defer tty.Close()
+
+	for i, f := range files {
+		fmt.Fprintf(tty, "%4d  %s\n", i+1, f)
+	}
+	fmt.Fprint(tty, "open which files? (e.g. 1 3 5-7, empty to cancel) ")
+
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, errPickCancelled
+	}
+	return parseSelection(line, files)
+}
+
+// parseSelection maps space- or comma-separated numbers and ranges such
+// as "1 3 5-7" to the files they name, counting from 1.
+func parseSelection(line string, files []string) (map[string]bool, error) {
+	chosen := make(map[string]bool)
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t' || r == '\n' || r == '\r'
+	})
+	for _, field := range fields {
+		lo, hi, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", field)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(hi); err != nil {
+				return nil, fmt.Errorf("invalid selection %q", field)
+			}
+		}
+		if start < 1 || end > len(files) || start > end {
+			return nil, fmt.Errorf("selection %q out of range 1-%d", field, len(files))
+		}
+		for i := start; i <= end; i++ {
+			chosen[files[i-1]] = true
+		}
+	}
+	return chosen, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	files := []string{"a.py", "b.py", "c.py", "d.py", "e.py"}
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"1", []string{"a.py"}},
+		{"5", []string{"e.py"}},
+		{"1 3", []string{"a.py", "c.py"}},
+		{"1,3", []string{"a.py", "c.py"}},
+		{" 2 ,\t4\r\n", []string{"b.py", "d.py"}},
+		{"2-4", []string{"b.py", "c.py", "d.py"}},
+		{"3-3", []string{"c.py"}},
+		{"1 2-3 2", []string{"a.py", "b.py", "c.py"}},
+		{"1-5", files},
+	}
+	for _, tt := range tests {
+		got, err := parseSelection(tt.in, files)
+		if err != nil {
+			t.Errorf("parseSelection(%q): %v", tt.in, err)
+			continue
+		}
+		var chosen []string
+		for _, f := range files {
+			if got[f] {
+				chosen = append(chosen, f)
+			}
+		}
+		if len(got) != len(chosen) || !slices.Equal(chosen, tt.want) {
+			t.Errorf("parseSelection(%q) = %v, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSelectionErrors(t *testing.T) {
+	files := []string{"a.py", "b.py", "c.py"}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"x", "invalid selection"},
+		{"1 x", "invalid selection"},
+		{"1-", "invalid selection"},
+		{"-2", "invalid selection"},
+		{"1-x", "invalid selection"},
+		{"0", "out of range 1-3"},
+		{"4", "out of range 1-3"},
+		{"2-4", "out of range 1-3"},
+		{"3-1", "out of range 1-3"},
+	}
+	for _, tt := range tests {
+		_, err := parseSelection(tt.in, files)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseSelection(%q) error = %v, want it to mention %q", tt.in, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// killProcessGroup is a no-op where process groups are unavailable;
+// cancellation kills only the editor process.
+func killProcessGroup(cmd *exec.Cmd, tty *os.File) func() {
+	return func() {}
+}
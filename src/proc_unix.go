@@ -0,0 +1,43 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// killProcessGroup starts cmd in its own process group, in the
+// foreground of tty when it is a terminal, and makes cancellation kill
+// the whole group so children of the editor die with it. The returned
+// function hands the terminal back to pyvim once cmd has exited.
+func killProcessGroup(cmd *exec.Cmd, tty *os.File) func() {
+	restore := func() {}
+	switch {
+	case tty == nil || !isTerminal(tty):
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	case canForeground:
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Foreground: true, Ctty: int(tty.Fd())}
+		restore = func() {
+			// pyvim is now in a background group, which the terminal
+			// would stop with SIGTTOU
+			signal.Ignore(syscall.SIGTTOU)
+			defer signal.Reset(syscall.SIGTTOU)
+			if err := setForeground(tty); err != nil {
+				warnf("failed to restore the terminal: %v", err)
+			}
+		}
+	default:
+		// Without a way to take the terminal back the editor stays in
+		// pyvim's group, and a timeout kills only the editor
+		return restore
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	return restore
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prepareSession creates the directory holding the session file at
+// path and reports whether a saved session already exists there.
+func prepareSession(path string) (bool, error) {
+	if sessionExists(path) {
+		return true, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create session directory: %v", err)
+	}
+	return false, nil
+}
+
+// sessionExists reports whether path holds a session to restore.
+func sessionExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// sessionArgs returns the arguments that restore the session at path,
+// or that save a new session there when the editor exits.
+func sessionArgs(path string, restore bool) []string {
+	if restore {
+		return []string{"-S", path}
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, " ", `\ `, "|", `\|`).Replace(path)
+	return []string{"--cmd", "autocmd VimLeave * mksession! " + escaped}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultArgsEnv names the environment variable holding default
+// arguments for the edit and list commands.
+const defaultArgsEnv = "PYVIM_DEFAULT_ARGS"
+
+// defaultArgs returns the split value of PYVIM_DEFAULT_ARGS, or nil when
+// it is unset or empty.
+func defaultArgs() ([]string, error) {
+	value := os.Getenv(defaultArgsEnv)
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	args, err := splitShell(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", defaultArgsEnv, err)
+	}
+	return args, nil
+}
+
+// splitShell splits s into words the way a POSIX shell would, honouring
+// single quotes, double quotes, and backslash escapes. It does not
+// expand variables or globs.
+func splitShell(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			i++
+			word.WriteByte(s[i])
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
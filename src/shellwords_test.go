@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitShell(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"   \t\n", nil},
+		{"-r --tabs", []string{"-r", "--tabs"}},
+		{"  -r   --tabs  ", []string{"-r", "--tabs"}},
+		{`--editor 'my vim'`, []string{"--editor", "my vim"}},
+		{`--editor "my vim"`, []string{"--editor", "my vim"}},
+		{`'it''s'`, []string{"its"}},
+		{`""`, []string{""}},
+		{`''`, []string{""}},
+		{`a\ b`, []string{"a b"}},
+		{`\'`, []string{"'"}},
+		{`"say \"hi\""`, []string{`say "hi"`}},
+		{`"a\b"`, []string{`a\b`}},
+		{`"\\ \$ \` + "`" + `"`, []string{"\\ $ `"}},
+		{`'a\b'`, []string{`a\b`}},
+		{`pre"mid"'post'`, []string{"premidpost"}},
+		{`-- -c "set nu"`, []string{"--", "-c", "set nu"}},
+		{"$HOME *.py", []string{"$HOME", "*.py"}},
+	}
+	for _, tt := range tests {
+		got, err := splitShell(tt.in)
+		if err != nil {
+			t.Errorf("splitShell(%q): %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitShell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplitShellErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`'open`, "unterminated single quote"},
+		{`--editor 'my vim`, "unterminated single quote"},
+		{`"open`, "unterminated double quote"},
+		{`"escaped end\"`, "unterminated double quote"},
+		{`trailing\`, "trailing backslash"},
+	}
+	for _, tt := range tests {
+		_, err := splitShell(tt.in)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("splitShell(%q) error = %v, want %q", tt.in, err, tt.want)
+		}
+	}
+}
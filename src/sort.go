@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// validateSort reports an error for an unknown --sort value.
+func validateSort(mode string) error {
+	switch mode {
+	case "name", "mtime", "size":
+		return nil
+	}
+	return fmt.Errorf("invalid --sort %q: must be one of name, mtime, size", mode)
+}
+
+// sortFiles orders files in place by mode. For mtime and size, files
+// that cannot be stat'ed are warned about and placed last by name.
+func sortFiles(files []string, mode string) {
+	sort.Strings(files)
+	if mode == "name" {
+		return
+	}
+
+	keys := make(map[string]int64, len(files))
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			warnf("cannot stat %s, sorting by name: %v", f, err)
+			continue
+		}
+		if mode == "mtime" {
+			keys[f] = info.ModTime().UnixNano()
+		} else {
+			keys[f] = info.Size()
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		ki, iok := keys[files[i]]
+		kj, jok := keys[files[j]]
+		if iok != jok {
+			return iok
+		}
+		return iok && ki < kj
+	})
+}
+
+// newestFile returns the most recently modified of files as a single
+// element slice, skipping files that cannot be stat'ed.
+func newestFile(files []string) []string {
+	var newest string
+	var newestTime time.Time
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			warnf("skipping %s: %v", f, err)
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = f, info.ModTime()
+		}
+	}
+	if newest == "" {
+		return nil
+	}
+	return []string{newest}
+}
@@ -0,0 +1,30 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// canForeground reports whether setForeground is available.
+const canForeground = true
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
+
+// setForeground makes pyvim's process group the foreground group of the
+// terminal f.
+func setForeground(f *os.File) error {
+	pgrp := int32(syscall.Getpgrp())
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&pgrp)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
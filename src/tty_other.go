@@ -0,0 +1,23 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// canForeground reports whether setForeground is available.
+const canForeground = false
+
+// isTerminal reports whether f is a character device, the closest
+// portable approximation of a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// setForeground is unsupported without terminal ioctls.
+func setForeground(f *os.File) error {
+	return errors.ErrUnsupported
+}
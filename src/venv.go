@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// venvNames lists the virtualenv directory names to look for, in order
+// of preference.
+var venvNames = []string{".venv", "venv"}
+
+// venvBinDir returns the directory holding a virtualenv's executables.
+func venvBinDir(venv string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(venv, "Scripts")
+	}
+	return filepath.Join(venv, "bin")
+}
+
+// findVenv returns the preferred virtualenv directly inside dir, or ""
+// if there is none.
+func findVenv(dir string) string {
+	var found []string
+	for _, name := range venvNames {
+		venv := filepath.Join(dir, name)
+		if info, err := os.Stat(venvBinDir(venv)); err == nil && info.IsDir() {
+			found = append(found, venv)
+		}
+	}
+	if len(found) == 0 {
+		return ""
+	}
+	if len(found) > 1 {
+		verbose.Printf("found virtualenvs %s, using %s", strings.Join(found, ", "), found[0])
+	}
+	return found[0]
+}
+
+// venvEnv returns env with VIRTUAL_ENV set to venv and its executables
+// prepended to PATH.
+func venvEnv(env []string, venv string) []string {
+	path := venvBinDir(venv)
+	var out []string
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		switch {
+		case strings.EqualFold(key, "PATH"):
+			path += string(os.PathListSeparator) + value
+		case key == "VIRTUAL_ENV":
+		default:
+			out = append(out, kv)
+		}
+	}
+	return append(out, "VIRTUAL_ENV="+venv, "PATH="+path)
+}
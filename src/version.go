@@ -0,0 +1,16 @@
+package main
+
+import "fmt"
+
+// Build information, stamped by CI with
+//
+//	go build -ldflags "-X main.version=... -X main.commit=... -X main.date=..."
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+func printVersion() {
+	fmt.Printf("pyvim %s (commit %s, built %s)\n", version, commit, date)
+}